@@ -0,0 +1,81 @@
+package mark
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// syntheticDoc builds a synthetic markdown document of roughly the
+// given size in bytes, repeating a mix of paragraphs, headings,
+// lists, and a fenced code block.
+func syntheticDoc(size int) string {
+	var b strings.Builder
+	para := "This is a **paragraph** with _emphasis_, `code`, and a [link](https://example.com \"title\").\n\n"
+	block := "## Section " + "\n\n" + para +
+		"- one\n- two\n- three\n\n" +
+		"```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```\n\n"
+	for b.Len() < size {
+		b.WriteString(strings.Replace(block, "Section", "Section "+strconv.Itoa(b.Len()), 1))
+	}
+	return b.String()
+}
+
+// BenchmarkRender and BenchmarkRenderTo report essentially the same
+// allocs/op: Render is now implemented as RenderTo into a buffer, so
+// they no longer isolate a streaming-vs-buffered win. The quadratic
+// cost the streaming change was meant to help with lived in parsing,
+// not rendering; see BenchmarkParseManyFences1x/4x below for a
+// benchmark that actually isolates that fix.
+func BenchmarkRender(b *testing.B) {
+	doc := syntheticDoc(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = New(doc, nil).Render()
+	}
+}
+
+func BenchmarkRenderTo(b *testing.B) {
+	doc := syntheticDoc(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := New(doc, nil)
+		if err := m.RenderTo(ioutil.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// manyFencesDoc builds a document of many small fenced code blocks,
+// the shape that used to trigger parseFencedCode's quadratic
+// behavior: each block re-joined and re-scanned the entire remainder
+// of the document for its closing fence.
+func manyFencesDoc(blocks int) string {
+	var b strings.Builder
+	for i := 0; i < blocks; i++ {
+		b.WriteString("```go\nfunc f" + strconv.Itoa(i) + "() {}\n```\n\n")
+	}
+	return b.String()
+}
+
+// BenchmarkParseManyFences1x and BenchmarkParseManyFences4x parse a
+// document with 4x as many fenced code blocks. ns/op scaling roughly
+// 4x between them (rather than ~16x) demonstrates that parsing a
+// fenced block no longer re-scans the whole remaining document, so
+// total parse cost stays linear in the number of blocks.
+func BenchmarkParseManyFences1x(b *testing.B) {
+	doc := manyFencesDoc(250)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = New(doc, nil)
+	}
+}
+
+func BenchmarkParseManyFences4x(b *testing.B) {
+	doc := manyFencesDoc(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = New(doc, nil)
+	}
+}
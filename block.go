@@ -0,0 +1,207 @@
+package mark
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	atxRe         = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	setextH1Re    = regexp.MustCompile(`^=+$`)
+	hrRe          = regexp.MustCompile(`^ {0,3}(\*\s*){3,}$|^ {0,3}(-\s*){3,}$|^ {0,3}(_\s*){3,}$`)
+	bulletItemRe  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	orderedItemRe = regexp.MustCompile(`^\d+\.\s+(.*)$`)
+)
+
+// parse runs the block-level parser over input and returns the
+// resulting document tree.
+func parse(m *Mark, input string) *DocumentNode {
+	doc := &DocumentNode{mark: m}
+	lines := strings.Split(input, "\n")
+	i := 0
+	for i < len(lines) {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+		var node Node
+		switch {
+		case isFenceStart(lines[i]):
+			node, i = parseFencedCode(m, lines, i)
+		case isIndentedCode(lines[i]):
+			node, i = parseIndentedCode(m, lines, i)
+		case isHr(lines[i]):
+			node, i = &HrNode{}, i+1
+		case atxRe.MatchString(lines[i]):
+			node, i = parseATXHeading(m, lines[i]), i+1
+		case isFootnoteDef(lines[i]):
+			doc.Footnotes = append(doc.Footnotes, parseFootnoteDef(m, lines[i]))
+			i++
+			continue
+		case isTableStart(lines, i):
+			node, i = parseTable(m, lines, i)
+		case isListStart(lines[i]):
+			node, i = parseList(m, lines, i, countLeadingSpaces(lines[i]))
+		default:
+			node, i = parseParagraph(m, lines, i)
+		}
+		doc.Nodes = append(doc.Nodes, node)
+	}
+	return doc
+}
+
+func isBlockStart(lines []string, i int) bool {
+	line := lines[i]
+	return isFenceStart(line) || isIndentedCode(line) || isHr(line) ||
+		atxRe.MatchString(line) || isListStart(line) || isFootnoteDef(line) ||
+		isTableStart(lines, i)
+}
+
+func isHr(line string) bool {
+	return hrRe.MatchString(line)
+}
+
+func parseATXHeading(m *Mark, line string) Node {
+	match := atxRe.FindStringSubmatch(line)
+	text := match[2]
+	return &HeadingNode{mark: m, Level: len(match[1]), ID: m.slugify(text), Nodes: parseInline(m, text)}
+}
+
+// parseParagraph consumes consecutive non-blank lines into a single
+// paragraph, stopping at a blank line or the start of another block.
+// A paragraph of exactly one line followed by a line of `===` becomes
+// a Setext level-1 heading instead.
+func parseParagraph(m *Mark, lines []string, i int) (Node, int) {
+	if i+1 < len(lines) && setextH1Re.MatchString(lines[i+1]) {
+		text := lines[i]
+		return &HeadingNode{mark: m, Level: 1, ID: m.slugify(text), Nodes: parseInline(m, text)}, i + 2
+	}
+	var raw []string
+	j := i
+	for j < len(lines) {
+		if strings.TrimSpace(lines[j]) == "" {
+			break
+		}
+		if j > i && isBlockStart(lines, j) {
+			break
+		}
+		raw = append(raw, lines[j])
+		j++
+	}
+	text := strings.Join(raw, "\n")
+	return &ParagraphNode{mark: m, Nodes: parseInline(m, text)}, j
+}
+
+func isFenceStart(line string) bool {
+	t := strings.TrimLeft(line, " ")
+	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+}
+
+// parseFencedCode parses a ``` or ~~~ fenced code block. The closing
+// fence is searched for as a plain substring of the remaining text, so
+// it need not sit alone on its own line.
+//
+// The fence itself never contains a newline, so a match can only ever
+// fall within a single line; this scans line by line instead of
+// re-joining and re-scanning the whole remainder of the document for
+// every fenced block, which made parsing quadratic in document size.
+func parseFencedCode(m *Mark, lines []string, i int) (Node, int) {
+	t := strings.TrimLeft(lines[i], " ")
+	fenceChar := t[0]
+	j := 0
+	for j < len(t) && t[j] == fenceChar {
+		j++
+	}
+	fence := strings.Repeat(string(fenceChar), j)
+	lang := strings.TrimSpace(t[j:])
+
+	var content strings.Builder
+	for k := i + 1; k < len(lines); k++ {
+		if k > i+1 {
+			content.WriteByte('\n')
+		}
+		if idx := strings.Index(lines[k], fence); idx != -1 {
+			content.WriteString(lines[k][:idx])
+			text := strings.TrimSuffix(content.String(), "\n")
+			return &CodeBlockNode{mark: m, Text: escapeHTML(text), Source: text, Lang: lang}, k + 1
+		}
+		content.WriteString(lines[k])
+	}
+	text := content.String()
+	return &CodeBlockNode{mark: m, Text: escapeHTML(text), Source: text, Lang: lang}, len(lines)
+}
+
+func isIndentedCode(line string) bool {
+	return strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ")
+}
+
+// parseIndentedCode consumes consecutive tab- or 4-space-indented
+// lines into a single code block.
+func parseIndentedCode(m *Mark, lines []string, i int) (Node, int) {
+	var raw []string
+	j := i
+	for j < len(lines) && isIndentedCode(lines[j]) {
+		l := lines[j]
+		if strings.HasPrefix(l, "\t") {
+			l = l[1:]
+		} else {
+			l = l[4:]
+		}
+		raw = append(raw, l)
+		j++
+	}
+	content := strings.Join(raw, "\n")
+	if j < len(lines) {
+		content += "\n"
+	}
+	return &CodeBlockNode{mark: m, Text: escapeHTML(content), Source: content}, j
+}
+
+func isListStart(line string) bool {
+	t := strings.TrimLeft(line, " ")
+	return bulletItemRe.MatchString(t) || orderedItemRe.MatchString(t)
+}
+
+func countLeadingSpaces(s string) int {
+	n := 0
+	for n < len(s) && s[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// parseList consumes a run of list items at the given indentation
+// level, recursing into parseList again whenever an item is followed
+// by a further-indented item (a nested sub-list).
+func parseList(m *Mark, lines []string, i int, indent int) (Node, int) {
+	first := lines[i][minInt(indent, len(lines[i])):]
+	ordered := orderedItemRe.MatchString(first)
+	list := &ListNode{mark: m, Ordered: ordered}
+	for i < len(lines) {
+		if countLeadingSpaces(lines[i]) < indent {
+			break
+		}
+		content := lines[i][indent:]
+		var match []string
+		if ordered {
+			match = orderedItemRe.FindStringSubmatch(content)
+		} else {
+			match = bulletItemRe.FindStringSubmatch(content)
+		}
+		if match == nil {
+			break
+		}
+		item := newListItem(m, match[1])
+		i++
+		if i < len(lines) {
+			nextIndent := countLeadingSpaces(lines[i])
+			if nextIndent > indent && isListStart(lines[i][nextIndent:]) {
+				var nested Node
+				nested, i = parseList(m, lines, i, nextIndent)
+				appendListItemChild(item, nested)
+			}
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, i
+}
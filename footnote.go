@@ -0,0 +1,94 @@
+package mark
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var (
+	footnoteDefRe   = regexp.MustCompile(`^\[\^([^\]]+)\]:\s*(.*)$`)
+	footnoteIDBadRe = regexp.MustCompile(`[^\w-]+`)
+)
+
+// sanitizeFootnoteID restricts a footnote id to a safe charset before
+// it's spliced into an `id="..."`/`href="#..."` attribute, much as the
+// default Slugger restricts heading ids.
+func sanitizeFootnoteID(id string) string {
+	return footnoteIDBadRe.ReplaceAllString(id, "-")
+}
+
+// FootnoteRefNode is an inline `[^id]` reference. The actual
+// definition is collected separately and rendered once, in order of
+// first reference, inside the trailing footnotes section. Number is
+// the running reference number shown to the reader; it's independent
+// of how descriptive or ugly ID is.
+type FootnoteRefNode struct {
+	ID     string
+	Number int
+}
+
+func (n *FootnoteRefNode) Type() NodeType { return NodeFootnoteRef }
+func (n *FootnoteRefNode) Render() string { return renderString(n) }
+func (n *FootnoteRefNode) RenderTo(w io.Writer) error {
+	num := strconv.Itoa(n.Number)
+	return writeStrings(w, "<sup id=\"fnref-"+n.ID+"\"><a href=\"#fn-"+n.ID+"\">"+num+"</a></sup>")
+}
+
+// FootnoteDefNode is a `[^id]: text` definition, rendered as one `<li>`
+// of the document's footnotes section.
+type FootnoteDefNode struct {
+	mark  *Mark
+	ID    string
+	Nodes []Node
+}
+
+func (n *FootnoteDefNode) Type() NodeType { return NodeFootnoteDef }
+func (n *FootnoteDefNode) Render() string { return renderString(n) }
+func (n *FootnoteDefNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<li id=\"fn-"+n.ID+"\">"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, " <a href=\"#fnref-"+n.ID+"\">↩</a></li>")
+}
+
+func isFootnoteDef(line string) bool {
+	return footnoteDefRe.MatchString(line)
+}
+
+func parseFootnoteDef(m *Mark, line string) *FootnoteDefNode {
+	match := footnoteDefRe.FindStringSubmatch(line)
+	return &FootnoteDefNode{mark: m, ID: sanitizeFootnoteID(match[1]), Nodes: parseInline(m, match[2])}
+}
+
+// reorderFootnotes sorts defs to match refOrder, the order in which
+// their ids were first referenced in the source, so the trailing
+// footnotes section lists them in reference order rather than
+// definition order. Definitions that are never referenced keep their
+// original relative order, appended at the end.
+func reorderFootnotes(defs []*FootnoteDefNode, refOrder []string) []*FootnoteDefNode {
+	if len(defs) == 0 || len(refOrder) == 0 {
+		return defs
+	}
+	byID := make(map[string]*FootnoteDefNode, len(defs))
+	for _, d := range defs {
+		byID[d.ID] = d
+	}
+	ordered := make([]*FootnoteDefNode, 0, len(defs))
+	used := make(map[string]bool, len(defs))
+	for _, id := range refOrder {
+		if d, ok := byID[id]; ok && !used[id] {
+			ordered = append(ordered, d)
+			used[id] = true
+		}
+	}
+	for _, d := range defs {
+		if !used[d.ID] {
+			ordered = append(ordered, d)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,61 @@
+package mark
+
+import "testing"
+
+func TestRenderGFM(t *testing.T) {
+	cases := map[string]string{
+		// Task lists
+		"- [ ] todo\n- [x] done": "<ul>\n" +
+			"<li><input type=\"checkbox\" disabled> todo</li>\n" +
+			"<li><input type=\"checkbox\" checked disabled> done</li>\n" +
+			"</ul>",
+		// Tables
+		"| a | b |\n|---|---|\n| 1 | 2 |": "<table>\n<thead>\n" +
+			"<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n" +
+			"<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>",
+		"| l | c | r |\n|:---|:---:|---:|\n| 1 | 2 | 3 |": "<table>\n<thead>\n" +
+			"<tr><th align=\"left\">l</th><th align=\"center\">c</th><th align=\"right\">r</th></tr>\n" +
+			"</thead>\n<tbody>\n" +
+			"<tr><td align=\"left\">1</td><td align=\"center\">2</td><td align=\"right\">3</td></tr>\n" +
+			"</tbody>\n</table>",
+		// A table with no preceding blank line still starts a new
+		// block instead of being swallowed into the paragraph above it.
+		"intro\n| a | b |\n|---|---|\n| 1 | 2 |": "<p>intro</p>\n<table>\n<thead>\n" +
+			"<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n" +
+			"<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>",
+		// Footnotes
+		"Here is a note.[^1]\n\n[^1]: The note text.": "<p>Here is a note." +
+			"<sup id=\"fnref-1\"><a href=\"#fn-1\">1</a></sup></p>\n" +
+			"<section class=\"footnotes\">\n<ol>\n" +
+			"<li id=\"fn-1\">The note text. <a href=\"#fnref-1\">↩</a></li>\n" +
+			"</ol>\n</section>",
+		// Footnotes are listed in order of first reference, not in the
+		// order their definitions appear in the source.
+		"a[^2] b[^1]\n\n[^1]: one\n[^2]: two": "<p>a" +
+			"<sup id=\"fnref-2\"><a href=\"#fn-2\">1</a></sup> b" +
+			"<sup id=\"fnref-1\"><a href=\"#fn-1\">2</a></sup></p>\n" +
+			"<section class=\"footnotes\">\n<ol>\n" +
+			"<li id=\"fn-2\">two <a href=\"#fnref-2\">↩</a></li>\n" +
+			"<li id=\"fn-1\">one <a href=\"#fnref-1\">↩</a></li>\n" +
+			"</ol>\n</section>",
+		// Footnote ids are restricted to a safe charset, even when the
+		// source tries to smuggle attribute syntax through them.
+		"note[^1\" onmouseover=\"alert(1)]\n\n[^1\" onmouseover=\"alert(1)]: def": "<p>note" +
+			"<sup id=\"fnref-1-onmouseover-alert-1-\"><a href=\"#fn-1-onmouseover-alert-1-\">1</a></sup></p>\n" +
+			"<section class=\"footnotes\">\n<ol>\n" +
+			"<li id=\"fn-1-onmouseover-alert-1-\">def <a href=\"#fnref-1-onmouseover-alert-1-\">↩</a></li>\n" +
+			"</ol>\n</section>",
+		// The visible marker is a running reference number, not the
+		// literal id text, so descriptive ids render sensibly.
+		"note[^long-name]\n\n[^long-name]: the text": "<p>note" +
+			"<sup id=\"fnref-long-name\"><a href=\"#fn-long-name\">1</a></sup></p>\n" +
+			"<section class=\"footnotes\">\n<ol>\n" +
+			"<li id=\"fn-long-name\">the text <a href=\"#fnref-long-name\">↩</a></li>\n" +
+			"</ol>\n</section>",
+	}
+	for actual, expected := range cases {
+		if res := Render(actual); res != expected {
+			t.Errorf("%s: got\n%+v\nexpected\n%+v", actual, res, expected)
+		}
+	}
+}
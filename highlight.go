@@ -0,0 +1,93 @@
+package mark
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Highlighter renders the source of a code block for the given
+// language tag (empty for indented blocks and untagged fences),
+// returning ok == false to fall back to the default escaped <code>
+// rendering.
+type Highlighter func(lang, source string) (html string, ok bool)
+
+// highlight runs opts.Highlighter over a code block's raw (unescaped)
+// source and wraps its output, or reports ok == false when no
+// highlighter is configured or it declined the block.
+func highlight(opts *Options, lang, source string) (html string, ok bool) {
+	if opts.Highlighter == nil {
+		return "", false
+	}
+	out, ok := opts.Highlighter(lang, source)
+	if !ok {
+		return "", false
+	}
+	if opts.CodeLineNumbers {
+		out = addLineNumbers(out)
+	}
+	class := opts.CodeWrapperClass
+	if class == "" {
+		class = "chroma"
+	}
+	return "<pre class=\"" + class + "\">" + out + "</pre>", true
+}
+
+// addLineNumbers wraps each line of htm in a `<span class="ln"
+// data-line="N">` gutter marker. htm is tokenized rather than split
+// naively on "\n": a tag a highlighter opens on one line and closes on
+// a later one (routine for multi-line string/comment tokens) is
+// closed and reopened around each line-number span it crosses, so the
+// result stays well-formed instead of leaving unbalanced tags behind.
+func addLineNumbers(htm string) string {
+	z := html.NewTokenizer(strings.NewReader(htm))
+	var out strings.Builder
+	var openTags, openRaw []string
+	line := 1
+	openLine := func() {
+		out.WriteString("<span class=\"ln\" data-line=\"" + strconv.Itoa(line) + "\">")
+		for _, raw := range openRaw {
+			out.WriteString(raw)
+		}
+	}
+	closeLine := func() {
+		for i := len(openTags) - 1; i >= 0; i-- {
+			out.WriteString("</" + openTags[i] + ">")
+		}
+		out.WriteString("</span>\n")
+		line++
+	}
+	openLine()
+	for {
+		tt := z.Next()
+		raw := string(z.Raw())
+		switch tt {
+		case html.ErrorToken:
+			closeLine()
+			return out.String()
+		case html.TextToken:
+			parts := strings.Split(raw, "\n")
+			for i, part := range parts {
+				out.WriteString(part)
+				if i < len(parts)-1 {
+					closeLine()
+					openLine()
+				}
+			}
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			openTags = append(openTags, string(name))
+			openRaw = append(openRaw, raw)
+			out.WriteString(raw)
+		case html.EndTagToken:
+			if len(openTags) > 0 {
+				openTags = openTags[:len(openTags)-1]
+				openRaw = openRaw[:len(openRaw)-1]
+			}
+			out.WriteString(raw)
+		default:
+			out.WriteString(raw)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package mark
+
+import "testing"
+
+func TestHighlighter(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Highlighter = func(lang, source string) (string, bool) {
+		return "<span class=\"kw\">" + source + "</span>", true
+	}
+	got := New("```js\nvar a;\n```", opts).Render()
+	want := "<pre class=\"chroma\"><span class=\"kw\">var a;</span></pre>"
+	if got != want {
+		t.Errorf("Highlighter: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestHighlighterCodeWrapperClass(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CodeWrapperClass = "highlight"
+	opts.Highlighter = func(lang, source string) (string, bool) {
+		return "<span class=\"kw\">" + source + "</span>", true
+	}
+	got := New("```js\nvar a;\n```", opts).Render()
+	want := "<pre class=\"highlight\"><span class=\"kw\">var a;</span></pre>"
+	if got != want {
+		t.Errorf("CodeWrapperClass: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestHighlighterDeclines(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Highlighter = func(lang, source string) (string, bool) {
+		return "", false
+	}
+	got := New("```js\nvar a;\n```", opts).Render()
+	want := "<pre><code class=\"lang-js\">var a;</code></pre>"
+	if got != want {
+		t.Errorf("Highlighter decline: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestCodeLineNumbers(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CodeLineNumbers = true
+	opts.Highlighter = func(lang, source string) (string, bool) {
+		return source, true
+	}
+	got := New("```\nfoo\nbar\n```", opts).Render()
+	want := "<pre class=\"chroma\">" +
+		"<span class=\"ln\" data-line=\"1\">foo</span>\n" +
+		"<span class=\"ln\" data-line=\"2\">bar</span>\n" +
+		"</pre>"
+	if got != want {
+		t.Errorf("CodeLineNumbers: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+// TestCodeLineNumbersSpanAcrossLines covers a highlighter emitting a
+// single tag that spans multiple lines (routine for multi-line string
+// or comment tokens): the tag must be closed and reopened around each
+// line's gutter marker so the result stays balanced.
+func TestCodeLineNumbersSpanAcrossLines(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CodeLineNumbers = true
+	opts.Highlighter = func(lang, source string) (string, bool) {
+		return "<span class=\"str\">" + source + "</span>", true
+	}
+	got := New("```\nfoo\nbar\n```", opts).Render()
+	want := "<pre class=\"chroma\">" +
+		"<span class=\"ln\" data-line=\"1\"><span class=\"str\">foo</span></span>\n" +
+		"<span class=\"ln\" data-line=\"2\"><span class=\"str\">bar</span></span>\n" +
+		"</pre>"
+	if got != want {
+		t.Errorf("CodeLineNumbers span across lines: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
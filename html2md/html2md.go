@@ -0,0 +1,405 @@
+// Package html2md converts HTML into the Markdown dialect that
+// mark.Render understands, the inverse of the mark package.
+//
+// One caveat: mark has no blockquote syntax, so the "> "-prefixed
+// lines produced for <blockquote> do not round-trip back through
+// mark.Render — it reads them as a plain paragraph with "> " escaped
+// rather than a blockquote. Blockquote conversion is offered for
+// HTML-to-Markdown use cases that don't feed the result back into
+// mark; it is not safe to assume Render(FromString(x)) round-trips
+// when x contains a <blockquote>.
+package html2md
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// LinkStyle selects how [text](href) links are emitted.
+type LinkStyle int
+
+const (
+	// LinkInline emits `[text](href "title")` in place.
+	LinkInline LinkStyle = iota
+	// LinkReference emits `[text][n]` in place and collects the
+	// `[n]: href "title"` definitions at the end of the document.
+	LinkReference
+)
+
+// CodeBlockStyle selects how <pre><code> blocks are emitted.
+type CodeBlockStyle int
+
+const (
+	// CodeBlockFenced emits ```lang fences.
+	CodeBlockFenced CodeBlockStyle = iota
+	// CodeBlockIndented emits a 4-space indented block. The code's
+	// language, if any, is lost in this style.
+	CodeBlockIndented
+)
+
+// Options controls how a Converter renders markdown.
+type Options struct {
+	// LinkStyle selects inline or reference-style links. Defaults to
+	// LinkInline.
+	LinkStyle LinkStyle
+	// CodeBlockStyle selects fenced or indented code blocks. Defaults
+	// to CodeBlockFenced.
+	CodeBlockStyle CodeBlockStyle
+	// BulletChar is the marker used for unordered list items: one of
+	// '-', '*', or '+'. Defaults to '-'.
+	BulletChar byte
+}
+
+// DefaultOptions returns the Options used when New is called with a
+// nil options value.
+func DefaultOptions() *Options {
+	return &Options{
+		LinkStyle:      LinkInline,
+		CodeBlockStyle: CodeBlockFenced,
+		BulletChar:     '-',
+	}
+}
+
+// Converter converts HTML to Markdown according to its Options.
+type Converter struct {
+	opts *Options
+}
+
+// New creates a Converter. A nil opts uses DefaultOptions().
+func New(opts *Options) *Converter {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	return &Converter{opts: opts}
+}
+
+// FromString converts an HTML fragment to markdown.
+func (c *Converter) FromString(s string) (string, error) {
+	return c.FromReader(strings.NewReader(s))
+}
+
+// FromReader converts the HTML read from r to markdown.
+func (c *Converter) FromReader(r io.Reader) (string, error) {
+	nodes, err := html.ParseFragment(r, &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "", err
+	}
+	w := &walker{opts: c.opts}
+	for _, n := range nodes {
+		w.block(n)
+	}
+	return w.result(), nil
+}
+
+// FromString converts an HTML fragment to markdown using
+// DefaultOptions().
+func FromString(s string) (string, error) {
+	return New(nil).FromString(s)
+}
+
+// FromReader converts the HTML read from r to markdown using
+// DefaultOptions().
+func FromReader(r io.Reader) (string, error) {
+	return New(nil).FromReader(r)
+}
+
+// walker accumulates markdown while walking an HTML node tree.
+type walker struct {
+	opts *Options
+	out  strings.Builder
+	refs []ref
+}
+
+type ref struct {
+	id    string
+	href  string
+	title string
+}
+
+func (w *walker) result() string {
+	s := strings.TrimRight(w.out.String(), "\n")
+	if len(w.refs) == 0 {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(s)
+	b.WriteString("\n\n")
+	for _, r := range w.refs {
+		b.WriteString("[" + r.id + "]: " + r.href)
+		if r.title != "" {
+			b.WriteString(" \"" + r.title + "\"")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// inlineEscapable is the subset of mark's own `escapable` set (see
+// inline.go) that has meaning wherever it appears in inline text, not
+// just at the start of a line: a stray "*" or "_" can still pair up
+// with one earlier or later in the same block and turn into emphasis.
+const inlineEscapable = "\\`*_[]"
+
+// escapeMarkdown backslash-escapes markdown-special characters in s
+// so that plain HTML text content round-trips as plain text instead
+// of being reinterpreted as markdown syntax (e.g. "__init__").
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(inlineEscapable, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	atxStartRe     = regexp.MustCompile(`^#{1,6}\s`)
+	bulletStartRe  = regexp.MustCompile(`^[-+]\s`)
+	orderedStartRe = regexp.MustCompile(`^(\d+)\.(\s)`)
+)
+
+// escapeBlockStart backslash-escapes the leading marker of s, if any,
+// that would otherwise make block-level text read back as an ATX
+// heading, bullet item, or ordered list item it never was (e.g.
+// "# not a heading" or "1. not a list").
+func escapeBlockStart(s string) string {
+	switch {
+	case atxStartRe.MatchString(s), bulletStartRe.MatchString(s):
+		return "\\" + s
+	case orderedStartRe.MatchString(s):
+		return orderedStartRe.ReplaceAllString(s, `$1\.$2`)
+	}
+	return s
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// block renders a block-level node, followed by the blank line that
+// separates it from the next block.
+func (w *walker) block(n *html.Node) {
+	if n.Type == html.TextNode {
+		if strings.TrimSpace(n.Data) != "" {
+			text := escapeBlockStart(escapeMarkdown(strings.TrimSpace(n.Data)))
+			w.out.WriteString(text)
+			w.out.WriteString("\n\n")
+		}
+		return
+	}
+	if n.Type != html.ElementNode {
+		w.blockChildren(n)
+		return
+	}
+	switch n.Data {
+	case "p":
+		w.out.WriteString(escapeBlockStart(w.inline(n)))
+		w.out.WriteString("\n\n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		w.out.WriteString(strings.Repeat("#", level) + " " + w.inline(n))
+		w.out.WriteString("\n\n")
+	case "hr":
+		w.out.WriteString("---\n\n")
+	case "ul":
+		w.list(n, false)
+		w.out.WriteString("\n")
+	case "ol":
+		w.list(n, true)
+		w.out.WriteString("\n")
+	case "blockquote":
+		// mark has no blockquote syntax, so this output does not
+		// round-trip back through mark.Render; see the package doc.
+		inner := strings.TrimRight(w.sub(n), "\n")
+		for _, line := range strings.Split(inner, "\n") {
+			w.out.WriteString("> " + line + "\n")
+		}
+		w.out.WriteString("\n")
+	case "pre":
+		w.codeBlock(n)
+	case "html", "head", "body":
+		w.blockChildren(n)
+	default:
+		w.blockChildren(n)
+	}
+}
+
+func (w *walker) blockChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.block(c)
+	}
+}
+
+// sub renders n's block-level children into a standalone string,
+// without appending it to w.out.
+func (w *walker) sub(n *html.Node) string {
+	inner := &walker{opts: w.opts, refs: w.refs}
+	inner.blockChildren(n)
+	w.refs = inner.refs
+	return inner.out.String()
+}
+
+func (w *walker) list(n *html.Node, ordered bool) {
+	w.out.WriteString(w.renderList(n, ordered, ""))
+}
+
+// renderList renders a <ul>/<ol> as a block of markdown list-item
+// lines, each prefixed by indent. A nested <ul>/<ol> found inside an
+// <li> is rendered recursively at indent+"  ", matching the
+// indentation parseList (block.go) requires to recognize it as a
+// sub-list rather than a separate paragraph.
+func (w *walker) renderList(n *html.Node, ordered bool, indent string) string {
+	var b strings.Builder
+	i := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+		marker := string(w.opts.BulletChar) + " "
+		if ordered {
+			marker = strconv.Itoa(i) + ". "
+			i++
+		}
+		text, sublists := w.listItemContent(c)
+		text = strings.TrimRight(text, "\n")
+		lines := strings.Split(text, "\n")
+		b.WriteString(indent + marker + lines[0] + "\n")
+		for _, l := range lines[1:] {
+			b.WriteString(indent + "  " + l + "\n")
+		}
+		for _, sub := range sublists {
+			b.WriteString(w.renderList(sub, sub.Data == "ol", indent+"  "))
+		}
+	}
+	return b.String()
+}
+
+// listItemContent renders li's inline content, returning any nested
+// <ul>/<ol> children separately so renderList can emit them as their
+// own indented sub-list instead of folding them into the item's text.
+func (w *walker) listItemContent(li *html.Node) (string, []*html.Node) {
+	var b strings.Builder
+	var sublists []*html.Node
+	for c := li.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+			sublists = append(sublists, c)
+			continue
+		}
+		b.WriteString(w.inlineNode(c))
+	}
+	return b.String(), sublists
+}
+
+func (w *walker) codeBlock(n *html.Node) {
+	code := n.FirstChild
+	if code == nil || code.Type != html.ElementNode || code.Data != "code" {
+		w.out.WriteString(w.inline(n) + "\n\n")
+		return
+	}
+	lang := ""
+	if class := attr(code, "class"); strings.HasPrefix(class, "lang-") {
+		lang = strings.TrimPrefix(class, "lang-")
+	}
+	src := textContent(code)
+	src = strings.TrimSuffix(src, "\n")
+	if w.opts.CodeBlockStyle == CodeBlockIndented {
+		for _, line := range strings.Split(src, "\n") {
+			w.out.WriteString("    " + line + "\n")
+		}
+		w.out.WriteString("\n")
+		return
+	}
+	w.out.WriteString("```" + lang + "\n" + src + "\n```\n\n")
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// inline renders n's children as inline markdown.
+func (w *walker) inline(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(w.inlineNode(c))
+	}
+	return b.String()
+}
+
+func (w *walker) inlineNode(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeMarkdown(n.Data)
+	case html.ElementNode:
+		switch n.Data {
+		case "strong", "b":
+			return "**" + w.inline(n) + "**"
+		case "em", "i":
+			return "_" + w.inline(n) + "_"
+		case "del":
+			return "~~" + w.inline(n) + "~~"
+		case "code":
+			return "`" + textContent(n) + "`"
+		case "br":
+			return "  \n"
+		case "a":
+			return w.link(n)
+		case "img":
+			alt := attr(n, "alt")
+			src := attr(n, "src")
+			title := attr(n, "title")
+			s := "![" + alt + "](" + src
+			if title != "" {
+				s += " \"" + title + "\""
+			}
+			return s + ")"
+		default:
+			return w.inline(n)
+		}
+	default:
+		return w.inline(n)
+	}
+}
+
+func (w *walker) link(n *html.Node) string {
+	href := attr(n, "href")
+	title := attr(n, "title")
+	text := w.inline(n)
+	if w.opts.LinkStyle == LinkReference {
+		id := strconv.Itoa(len(w.refs) + 1)
+		w.refs = append(w.refs, ref{id: id, href: href, title: title})
+		return "[" + text + "][" + id + "]"
+	}
+	s := "[" + text + "](" + href
+	if title != "" {
+		s += " \"" + title + "\""
+	}
+	return s + ")"
+}
@@ -0,0 +1,100 @@
+package html2md
+
+import (
+	"testing"
+
+	"github.com/frank3nst3in/mark"
+)
+
+func TestFromString(t *testing.T) {
+	cases := map[string]string{
+		"<p>foobar</p>":                                    "foobar\n",
+		"<p>foo <strong>bar</strong></p>":                  "foo **bar**\n",
+		"<p>foo <em>bar</em></p>":                          "foo _bar_\n",
+		"<p>foo <del>bar</del></p>":                        "foo ~~bar~~\n",
+		"<p><code>bool</code> and thats it.</p>":           "`bool` and thats it.\n",
+		"<h1 id=\"1\">1</h1>":                              "# 1\n",
+		"<h2 id=\"2\">2</h2>":                              "## 2\n",
+		"<p><a href=\"link\" title=\"title\">text</a></p>": "[text](link \"title\")\n",
+		"<p><a href=\"link\">text</a></p>":                 "[text](link)\n",
+		"<p><img src=\"url\" alt=\"name\"></p>":            "![name](url)\n",
+		"<hr>":                                             "---\n",
+	}
+	for in, want := range cases {
+		got, err := FromString(in)
+		if err != nil {
+			t.Fatalf("FromString(%q): %v", in, err)
+		}
+		if got+"\n" != want {
+			t.Errorf("FromString(%q): got\n\t%q\nexpected\n\t%q", in, got, want)
+		}
+	}
+}
+
+// TestRoundTrip feeds mark_test.go's TestRender expected HTML values
+// back through html2md and checks that mark.Render of the result
+// reproduces the original HTML. Three categories are intentionally
+// excluded, all documented rather than silently dropped:
+//   - Cases that rely on HTML entity decoding (e.g. "&copy;" decodes
+//     to "©" while parsing the HTML fragment, and mark has no reason
+//     to re-encode a literal "©" back to an entity). This is lossy by
+//     nature, not a bug in either package.
+//   - Cases built on mark's bare-URL autolinking ("http://...") where
+//     the link text is itself a URL: parseLink re-parses the link
+//     text as inline markdown, and mark's own autolinking turns it
+//     into a second, nested <a>. That's a pre-existing mark bug
+//     independent of html2md, tracked separately.
+//   - Code blocks whose content ends in a newline (e.g. the indented
+//     "\tfoo\nbar" case): codeBlock trims the trailing newline before
+//     emitting a fence, and mark trims it again inside a fence, so it
+//     does not come back.
+//
+// Markdown-special characters in plain text, hard line breaks, and
+// nested lists are all expected to round-trip and are exercised below.
+func TestRoundTrip(t *testing.T) {
+	cases := []string{
+		"<p>foobar</p>",
+		"<p>  foo bar</p>",
+		"<p>foo|bar</p>",
+		"<p>foo<br>bar</p>",
+		"<p>1<br>2<br>3</p>",
+		"<p><strong>bar</strong> foo</p>",
+		"<p><em>bar</em>baz</p>",
+		"<p><del>baz</del> <em>baz</em></p>",
+		"<p><code>bool</code> and thats it.</p>",
+		"<h1 id=\"1\">1</h1>\n<h2 id=\"2\">2</h2>",
+		"<p><a href=\"link\" title=\"title\">text</a></p>",
+		"<p><a href=\"link\">text</a></p>",
+		"<p><img src=\"url\" alt=\"name\"></p>",
+		"<p><img src=\"url\" alt=\"name\" title=\"title\"></p>",
+		"<ul>\n<li>foo</li>\n<li>bar</li>\n</ul>",
+		"<ol>\n<li>one</li>\n<li>two</li>\n<li>three</li>\n</ol>",
+		"<ol>\n<li>one<ol>\n<li>one of one</li>\n</ol></li>\n</ol>",
+		"<pre><code class=\"lang-js\">var a;</code></pre>",
+		"<pre><code>var b;</code></pre>",
+		// Attribute injection: href/src/lang are escaped on the way
+		// into mark's HTML and parsed back out by html2md unchanged.
+		`<p><a href="&quot;onmouseover=&quot;alert(1">x</a>)</p>`,
+		`<p><img src="&quot;onmouseover=&quot;alert" alt="x"></p>`,
+		"<pre><code class=\"lang-&quot;onmouseover=&quot;alert(1)\">var a;</code></pre>",
+		// Backslash-escaped markdown-special characters round-trip as
+		// plain text, not as the syntax they'd otherwise form.
+		"<p>*<em>foo*</em></p>",
+		"<p>*foo*</p>",
+		"<p>_underscores_</p>",
+		"<p>## header</p>",
+		"<p>header\n\\===</p>",
+		"<p>call __init__ method</p>",
+		"<p># not a heading, just a hashtag</p>",
+		"<p>1. not a list either</p>",
+	}
+	for _, html := range cases {
+		md, err := FromString(html)
+		if err != nil {
+			t.Fatalf("FromString(%q): %v", html, err)
+		}
+		if got := mark.Render(md); got != html {
+			t.Errorf("round trip %q: got\n\t%+v\nexpected\n\t%+v\n(via markdown %q)", html, got, html, md)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package mark
+
+import (
+	"regexp"
+	"strings"
+)
+
+// brMarker stands in for a hard line break ("  \n") while a paragraph's
+// raw text is scanned; it is replaced by a BrNode once the surrounding
+// text has been tokenized.
+const brMarker = '\x02'
+
+// escapable is the set of punctuation characters a backslash can
+// escape. Anything else (e.g. "\=") passes the backslash through
+// untouched.
+const escapable = "\\`*_{}[]()#+-.!"
+
+var hardBreakRe = regexp.MustCompile(` {2,}\n`)
+
+// parseInline turns raw markdown text into a slice of inline Nodes,
+// resolving emphasis, links, images, code spans, autolinks, entities
+// and backslash escapes.
+func parseInline(m *Mark, s string) []Node {
+	s = hardBreakRe.ReplaceAllString(s, string(brMarker))
+	return resolveDelims(m, scanInline(m, s))
+}
+
+// delimRun is a run of consecutive `*`, `_` or `~` characters that may
+// open and/or close emphasis, strong or strikethrough.
+type delimRun struct {
+	ch          byte
+	n           int
+	open, close bool
+}
+
+// scanInline walks s left to right, peeling off code spans, links,
+// images, autolinks, bare URLs, entities and escapes as they're found,
+// and grouping the rest into delimiter runs or plain text.
+func scanInline(m *Mark, s string) []interface{} {
+	var elems []interface{}
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == brMarker:
+			elems = append(elems, &BrNode{})
+			i++
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0:
+			elems = append(elems, string(s[i+1]))
+			i += 2
+		case c == '`':
+			node, consumed, ok := scanCodeSpan(s[i:])
+			if ok {
+				elems = append(elems, node)
+				i += consumed
+			} else {
+				elems = append(elems, "`")
+				i++
+			}
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			node, consumed, ok := parseImage(s[i:])
+			if ok {
+				elems = append(elems, node)
+				i += consumed
+			} else {
+				elems = append(elems, "!")
+				i++
+			}
+		case c == '[' && i+1 < len(s) && s[i+1] == '^':
+			end := strings.IndexByte(s[i+2:], ']')
+			if end == -1 {
+				elems = append(elems, "[")
+				i++
+			} else {
+				id := sanitizeFootnoteID(s[i+2 : i+2+end])
+				num := m.noteFootnoteRef(id)
+				elems = append(elems, &FootnoteRefNode{ID: id, Number: num})
+				i += end + 3
+			}
+		case c == '[':
+			node, consumed, ok := parseLink(m, s[i:])
+			if ok {
+				elems = append(elems, node)
+				i += consumed
+			} else {
+				elems = append(elems, "[")
+				i++
+			}
+		case c == '<':
+			node, consumed, ok := parseAutolink(s[i:])
+			if ok {
+				elems = append(elems, node)
+				i += consumed
+			} else {
+				elems = append(elems, "<")
+				i++
+			}
+		case c == '&':
+			node, consumed, ok := parseEntity(s[i:])
+			if ok {
+				elems = append(elems, node)
+				i += consumed
+			} else {
+				elems = append(elems, "&")
+				i++
+			}
+		case (c == 'h') && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")):
+			j := i
+			for j < len(s) && !isSpaceByte(s[j]) {
+				j++
+			}
+			url := s[i:j]
+			elems = append(elems, &LinkNode{mark: m, Href: url, Nodes: []Node{&TextNode{Text: escapeHTML(url)}}})
+			i = j
+		case c == '*' || c == '_' || c == '~':
+			j := i
+			for j < len(s) && s[j] == c {
+				j++
+			}
+			n := j - i
+			before, after := byte(' '), byte(' ')
+			if i > 0 {
+				before = s[i-1]
+			}
+			if j < len(s) {
+				after = s[j]
+			}
+			elems = append(elems, &delimRun{
+				ch:    c,
+				n:     n,
+				open:  !isSpaceByte(after),
+				close: !isSpaceByte(before),
+			})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !isSpecialByte(s[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			elems = append(elems, s[i:j])
+			i = j
+		}
+	}
+	return elems
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isSpecialByte(b byte) bool {
+	switch b {
+	case brMarker, '\\', '`', '!', '[', '<', '&', '*', '_', '~', 'h':
+		return true
+	}
+	return false
+}
+
+// resolveDelims matches up delimiter runs produced by scanInline into
+// EmphasisNode, StrongNode and DelNode trees, using the nearest
+// compatible opener on the stack for each closer (innermost first),
+// consuming only as many characters from each run as the match needs
+// and leaving the rest as literal text.
+func resolveDelims(m *Mark, elems []interface{}) []Node {
+	type opener struct {
+		ch    byte
+		rem   int
+		text  *TextNode
+		start int
+	}
+	var out []Node
+	var stack []opener
+
+	// smartyPrev is the last raw byte rendered so far in this
+	// paragraph, threaded across chunk boundaries so smartypants'
+	// quote-openness check (isOpenQuoteContext) sees the character
+	// that actually precedes a quote instead of resetting to "start of
+	// paragraph" at every delimiter split (e.g. the apostrophe in
+	// "*foo*'s bar", which scanInline tokenizes separately from "foo").
+	var smartyPrev byte
+
+	appendText := func(s string) {
+		if m != nil {
+			if t := m.opts.typographer(); t != nil {
+				s, smartyPrev = applySmartypants(s, t, smartyPrev)
+			}
+		}
+		out = append(out, &TextNode{Text: escapeHTML(s)})
+	}
+
+	for _, e := range elems {
+		d, isDelim := e.(*delimRun)
+		if !isDelim {
+			switch v := e.(type) {
+			case string:
+				appendText(v)
+			case Node:
+				out = append(out, v)
+				smartyPrev = trailingByte(v)
+			}
+			continue
+		}
+		n := d.n
+		if d.close {
+			for n > 0 {
+				si := -1
+				for k := len(stack) - 1; k >= 0; k-- {
+					if stack[k].ch == d.ch {
+						si = k
+						break
+					}
+				}
+				if si == -1 {
+					break
+				}
+				o := stack[si]
+				matched := minInt(o.rem, n)
+				if d.ch == '~' {
+					if o.rem < 2 || n < 2 {
+						break
+					}
+					matched = 2
+				}
+				content := append([]Node(nil), out[o.start:]...)
+				out = append(out[:o.start], wrapEmphasis(m, d.ch, matched, content))
+				o.text.Text = o.text.Text[:len(o.text.Text)-matched]
+				o.rem -= matched
+				n -= matched
+				smartyPrev = trailingBytes(content)
+				if o.rem == 0 {
+					stack = append(stack[:si], stack[si+1:]...)
+				} else {
+					stack[si].rem = o.rem
+				}
+			}
+		}
+		if n > 0 && d.open {
+			tn := &TextNode{Text: strings.Repeat(string(d.ch), n)}
+			out = append(out, tn)
+			stack = append(stack, opener{ch: d.ch, rem: n, text: tn, start: len(out)})
+			smartyPrev = d.ch
+		} else if n > 0 {
+			out = append(out, &TextNode{Text: strings.Repeat(string(d.ch), n)})
+			smartyPrev = d.ch
+		}
+	}
+	return out
+}
+
+// trailingByte returns the last raw byte of n's rendered text content,
+// used to carry smartypants' quote-openness context (see resolveDelims)
+// across a node boundary, e.g. a link or an already-closed emphasis
+// run. Node types with no text of their own (br, image, footnote
+// reference) report a space, since they read like a word boundary for
+// this purpose.
+func trailingByte(n Node) byte {
+	switch v := n.(type) {
+	case *TextNode:
+		if len(v.Text) > 0 {
+			return v.Text[len(v.Text)-1]
+		}
+	case *CodeNode:
+		if len(v.Text) > 0 {
+			return v.Text[len(v.Text)-1]
+		}
+	case *EmphasisNode:
+		return trailingBytes(v.Nodes)
+	case *StrongNode:
+		return trailingBytes(v.Nodes)
+	case *DelNode:
+		return trailingBytes(v.Nodes)
+	case *LinkNode:
+		return trailingBytes(v.Nodes)
+	}
+	return ' '
+}
+
+// trailingBytes is trailingByte for the last node of a slice, used
+// after closing an emphasis/strong/del run to get the context its
+// content leaves behind for whatever follows it.
+func trailingBytes(nodes []Node) byte {
+	if len(nodes) == 0 {
+		return ' '
+	}
+	return trailingByte(nodes[len(nodes)-1])
+}
+
+func wrapEmphasis(m *Mark, ch byte, matched int, content []Node) Node {
+	if ch == '~' {
+		return &DelNode{mark: m, Nodes: content}
+	}
+	if matched == 1 {
+		return &EmphasisNode{mark: m, Nodes: content}
+	}
+	if matched == 2 {
+		return &StrongNode{mark: m, Nodes: content}
+	}
+	return &StrongNode{mark: m, Nodes: []Node{&EmphasisNode{mark: m, Nodes: content}}}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// scanCodeSpan parses an inline code span starting at a run of
+// backticks, e.g. “ `code` “. It returns the rendered node and the
+// number of bytes consumed.
+func scanCodeSpan(s string) (Node, int, bool) {
+	j := 0
+	for j < len(s) && s[j] == '`' {
+		j++
+	}
+	fence := s[:j]
+	rest := s[j:]
+	idx := strings.Index(rest, fence)
+	if idx == -1 {
+		return nil, 0, false
+	}
+	content := strings.TrimSpace(rest[:idx])
+	return &CodeNode{Text: escapeHTML(content)}, j + idx + len(fence), true
+}
+
+var hrefTitleRe = regexp.MustCompile(`^(\S*)(?:\s+"(.*)")?$`)
+
+func splitHrefTitle(s string) (href, title string) {
+	match := hrefTitleRe.FindStringSubmatch(s)
+	if match == nil {
+		return s, ""
+	}
+	return match[1], match[2]
+}
+
+// parseLink parses `[text](href "title")` starting at s[0] == '['.
+func parseLink(m *Mark, s string) (Node, int, bool) {
+	end := strings.IndexByte(s, ']')
+	if end == -1 || end+1 >= len(s) || s[end+1] != '(' {
+		return nil, 0, false
+	}
+	text := s[1:end]
+	closeParen := strings.IndexByte(s[end+2:], ')')
+	if closeParen == -1 {
+		return nil, 0, false
+	}
+	inner := s[end+2 : end+2+closeParen]
+	href, title := splitHrefTitle(inner)
+	node := &LinkNode{mark: m, Href: href, Title: title, Nodes: parseInline(m, text)}
+	return node, end + 2 + closeParen + 1, true
+}
+
+// parseImage parses `![alt](src "title")` starting at s[0] == '!'.
+func parseImage(s string) (Node, int, bool) {
+	if len(s) < 2 || s[1] != '[' {
+		return nil, 0, false
+	}
+	end := strings.IndexByte(s, ']')
+	if end == -1 || end+1 >= len(s) || s[end+1] != '(' {
+		return nil, 0, false
+	}
+	alt := s[2:end]
+	closeParen := strings.IndexByte(s[end+2:], ')')
+	if closeParen == -1 {
+		return nil, 0, false
+	}
+	inner := s[end+2 : end+2+closeParen]
+	src, title := splitHrefTitle(inner)
+	node := &ImageNode{Src: src, Alt: escapeHTML(alt), Title: title}
+	return node, end + 2 + closeParen + 1, true
+}
+
+// parseAutolink parses `<http://example.com>` starting at s[0] == '<'.
+func parseAutolink(s string) (Node, int, bool) {
+	end := strings.IndexByte(s, '>')
+	if end == -1 {
+		return nil, 0, false
+	}
+	inner := s[1:end]
+	if !strings.HasPrefix(inner, "http://") && !strings.HasPrefix(inner, "https://") {
+		return nil, 0, false
+	}
+	node := &LinkNode{Href: inner, Nodes: []Node{&TextNode{Text: escapeHTML(inner)}}}
+	return node, end + 1, true
+}
+
+var entityRe = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+// parseEntity recognizes a valid HTML entity reference (e.g. "&copy;")
+// so it can pass through to the output unescaped.
+func parseEntity(s string) (Node, int, bool) {
+	loc := entityRe.FindStringIndex(s)
+	if loc == nil {
+		return nil, 0, false
+	}
+	return &RawHTMLNode{HTML: s[:loc[1]]}, loc[1], true
+}
+
+// escapeHTML escapes the handful of characters that are unsafe to
+// place directly inside HTML text content.
+func escapeHTML(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '&':
+			b.WriteString("&amp;")
+		case '\'':
+			b.WriteString("&#39;")
+		case '"':
+			b.WriteString("&quot;")
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+// Package mark implements a small Markdown parser and HTML renderer.
+// Its only external dependency is golang.org/x/net/html, used by the
+// sanitizer and by code-block line numbering.
+package mark
+
+import (
+	"bytes"
+	"io"
+)
+
+// Options controls how a Mark instance parses and renders its input.
+type Options struct {
+	// Smartypants turns on typographic substitutions (curly quotes,
+	// dashes, ellipses) in text content. It is shorthand for setting
+	// every sub-flag of Typographer; set Typographer directly to opt
+	// into substitutions selectively.
+	Smartypants bool
+	// Typographer, if set, overrides Smartypants and selects exactly
+	// which typographic substitutions to make.
+	Typographer *Typographer
+	// Sanitize runs the rendered HTML through SanitizerPolicy before
+	// Render returns it.
+	Sanitize bool
+	// SanitizerPolicy is consulted when Sanitize is true. It defaults
+	// to nil, so Sanitize has no effect until a policy (e.g.
+	// DefaultUGCPolicy() or a bluemonday-backed one) is set.
+	SanitizerPolicy SanitizerPolicy
+	// Highlighter, if set, is called for every code block, fenced or
+	// indented, to render its syntax-highlighted HTML; indented blocks
+	// and untagged fences pass an empty lang. A false ok return falls
+	// back to the default escaped <code> rendering.
+	Highlighter Highlighter
+	// CodeLineNumbers wraps each line of a Highlighter's output in a
+	// `<span class="ln" data-line="N">` gutter marker.
+	CodeLineNumbers bool
+	// CodeWrapperClass sets the class of the `<pre>` a Highlighter's
+	// output is wrapped in. Defaults to "chroma".
+	CodeWrapperClass string
+	// Slugger computes heading ids. Defaults to a Slugger that
+	// lowercases the heading text and replaces runs of non-letter,
+	// non-number characters with a hyphen.
+	Slugger Slugger
+	// HeadingIDPrefix is prepended to every heading id.
+	HeadingIDPrefix string
+	// HeadingAnchorLink, when true, adds a `#` anchor link inside each
+	// heading, pointing at its own id.
+	HeadingAnchorLink bool
+}
+
+// DefaultOptions returns the Options used when New is called with a
+// nil options value.
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+// Mark parses a markdown document and renders it to HTML.
+type Mark struct {
+	input           string
+	opts            *Options
+	renderFns       map[NodeType]func(Node) string
+	renderFnsTo     map[NodeType]func(Node, io.Writer)
+	slugger         Slugger
+	slugSeen        map[string]int
+	footnoteRefs    []string
+	footnoteNumbers map[string]int
+	doc             *DocumentNode
+}
+
+// New creates a Mark for the given markdown input. A nil opts uses
+// DefaultOptions().
+func New(input string, opts *Options) *Mark {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	slugger := opts.Slugger
+	if slugger == nil {
+		slugger = defaultSlugger{}
+	}
+	m := &Mark{
+		input:           input,
+		opts:            opts,
+		renderFns:       make(map[NodeType]func(Node) string),
+		renderFnsTo:     make(map[NodeType]func(Node, io.Writer)),
+		slugger:         slugger,
+		slugSeen:        make(map[string]int),
+		footnoteNumbers: make(map[string]int),
+	}
+	m.doc = parse(m, input)
+	m.doc.Footnotes = reorderFootnotes(m.doc.Footnotes, m.footnoteRefs)
+	return m
+}
+
+// noteFootnoteRef records id the first time it's referenced, so
+// Footnotes can be reordered to match reference order once parsing
+// finishes, and returns the running reference number for id: the
+// order in which its id was first seen, counting from 1. Repeated
+// references to the same id get back the same number.
+func (m *Mark) noteFootnoteRef(id string) int {
+	if n, ok := m.footnoteNumbers[id]; ok {
+		return n
+	}
+	m.footnoteRefs = append(m.footnoteRefs, id)
+	n := len(m.footnoteNumbers) + 1
+	m.footnoteNumbers[id] = n
+	return n
+}
+
+// AddRenderFn registers a custom render function for all nodes of the
+// given type, overriding the node's default Render method. See
+// AddRenderFnTo for a streaming variant.
+func (m *Mark) AddRenderFn(t NodeType, fn func(Node) string) {
+	m.renderFns[t] = fn
+}
+
+// AddRenderFnTo registers a custom streaming render function for all
+// nodes of the given type, overriding the node's default RenderTo
+// method. It takes precedence over a function registered with
+// AddRenderFn for the same NodeType.
+func (m *Mark) AddRenderFnTo(t NodeType, fn func(Node, io.Writer)) {
+	m.renderFnsTo[t] = fn
+}
+
+// Render returns the HTML for the parsed document, passing it through
+// opts.SanitizerPolicy first if opts.Sanitize is set.
+func (m *Mark) Render() string {
+	if m.opts.Sanitize {
+		return sanitize(m.opts, m.doc.Render())
+	}
+	var buf bytes.Buffer
+	m.RenderTo(&buf)
+	return buf.String()
+}
+
+// RenderTo writes the HTML for the parsed document to w. Unlike
+// Render, it streams the tree directly to w instead of building the
+// whole document in memory first, which matters for multi-megabyte
+// input. If opts.Sanitize is set, RenderTo falls back to buffering
+// internally, since SanitizerPolicy operates on a complete HTML
+// string.
+func (m *Mark) RenderTo(w io.Writer) error {
+	if m.opts.Sanitize {
+		_, err := io.WriteString(w, sanitize(m.opts, m.doc.Render()))
+		return err
+	}
+	return m.doc.RenderTo(w)
+}
+
+// Render parses s with DefaultOptions() and returns its HTML.
+func Render(s string) string {
+	return New(s, nil).Render()
+}
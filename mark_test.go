@@ -1,6 +1,8 @@
 package mark
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"regexp"
 	"strings"
@@ -48,13 +50,18 @@ func TestRender(t *testing.T) {
 		"<http://foo.com>":       "<p><a href=\"http://foo.com\">http://foo.com</a></p>",
 		"Link: <http://l.co>":    "<p>Link: <a href=\"http://l.co\">http://l.co</a></p>",
 		"Link: <not really":      "<p>Link: &lt;not really</p>",
+		// Attribute injection: href/title/src/lang are escaped before
+		// being written into attribute position, same as text content.
+		`[x]("onmouseover="alert(1))`: `<p><a href="&quot;onmouseover=&quot;alert(1">x</a>)</p>`,
+		`![x]("onmouseover="alert)`:   `<p><img src="&quot;onmouseover=&quot;alert" alt="x"></p>`,
 		// CodeBlock
 		"\tfoo\n\tbar": "<pre><code>foo\nbar</code></pre>",
 		"\tfoo\nbar":   "<pre><code>foo\n</code></pre>\n<p>bar</p>",
 		// GfmCodeBlock
-		"```js\nvar a;\n```":  "<pre><code class=\"lang-js\">var a;</code></pre>",
-		"~~~\nvar b;~~~":      "<pre><code>var b;</code></pre>",
-		"~~~js\nlet d = 1~~~": "<pre><code class=\"lang-js\">let d = 1</code></pre>",
+		"```js\nvar a;\n```":                       "<pre><code class=\"lang-js\">var a;</code></pre>",
+		"~~~\nvar b;~~~":                           "<pre><code>var b;</code></pre>",
+		"~~~js\nlet d = 1~~~":                      "<pre><code class=\"lang-js\">let d = 1</code></pre>",
+		"```\"onmouseover=\"alert(1)\nvar a;\n```": "<pre><code class=\"lang-&quot;onmouseover=&quot;alert(1)\">var a;</code></pre>",
 		// Hr
 		"foo\n****\nbar": "<p>foo</p>\n<hr>\n<p>bar</p>",
 		"foo\n___":       "<p>foo</p>\n<hr>",
@@ -145,3 +152,62 @@ func TestRenderFn(t *testing.T) {
 		t.Errorf("RenderFn: got\n\t%+v\nexpected\n\t%+v", actual, expected)
 	}
 }
+
+func TestSmartypants(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Smartypants = true
+	cases := map[string]string{
+		"foo---bar":                "<p>foo—bar</p>",
+		"foo--bar":                 "<p>foo–bar</p>",
+		"wait...":                  "<p>wait…</p>",
+		"She said \"hi\" to 'you'": "<p>She said “hi” to ‘you’</p>",
+		"don't":                    "<p>don’t</p>",
+		"(c) (r) (tm)":             "<p>© ® ™</p>",
+		"`foo--bar`":               "<p><code>foo--bar</code></p>",
+		"\tfoo--bar":               "<pre><code>foo--bar</code></pre>",
+		"*foo*'s bar":              "<p><em>foo</em>’s bar</p>",
+	}
+	for actual, expected := range cases {
+		if res := New(actual, opts).Render(); res != expected {
+			t.Errorf("Smartypants %q: got\n%+v\nexpected\n%+v", actual, res, expected)
+		}
+	}
+}
+
+func TestTypographerSubFlags(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Typographer = &Typographer{Dashes: true}
+	got := New("foo--bar...", opts).Render()
+	want := "<p>foo–bar...</p>"
+	if got != want {
+		t.Errorf("Typographer sub-flags: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestRenderTo(t *testing.T) {
+	m := New("# foo\n\nbar baz", nil)
+	var buf bytes.Buffer
+	if err := m.RenderTo(&buf); err != nil {
+		t.Fatalf("RenderTo: %v", err)
+	}
+	if got, want := buf.String(), m.Render(); got != want {
+		t.Errorf("RenderTo: got\n\t%+v\nexpected\n\t%+v", got, want)
+	}
+}
+
+func TestAddRenderFnTo(t *testing.T) {
+	m := New("hello world", nil)
+	m.AddRenderFnTo(NodeParagraph, func(n Node, w io.Writer) {
+		if p, ok := n.(*ParagraphNode); ok {
+			io.WriteString(w, "<p class=\"mv-msg\">")
+			for _, pp := range p.Nodes {
+				io.WriteString(w, pp.Render())
+			}
+			io.WriteString(w, "</p>")
+		}
+	})
+	expected := "<p class=\"mv-msg\">hello world</p>"
+	if actual := m.Render(); actual != expected {
+		t.Errorf("AddRenderFnTo: got\n\t%+v\nexpected\n\t%+v", actual, expected)
+	}
+}
@@ -0,0 +1,401 @@
+package mark
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// NodeType identifies the concrete type of a Node.
+type NodeType int
+
+// Node kinds produced by the parser.
+const (
+	NodeDocument NodeType = iota
+	NodeParagraph
+	NodeHeading
+	NodeText
+	NodeStrong
+	NodeEmphasis
+	NodeDel
+	NodeCode
+	NodeCodeBlock
+	NodeLink
+	NodeImage
+	NodeList
+	NodeListItem
+	NodeHr
+	NodeBr
+	NodeRawHTML
+	NodeTable
+	NodeTableRow
+	NodeTableCell
+	NodeTaskListItem
+	NodeFootnoteRef
+	NodeFootnoteDef
+)
+
+// Node is the common interface implemented by every element of the
+// parsed markdown tree.
+type Node interface {
+	// Type reports the kind of node, so callers (and AddRenderFn) can
+	// dispatch on it without a type switch.
+	Type() NodeType
+	// Render returns the HTML representation of the node and its
+	// children. It is a thin wrapper around RenderTo for callers that
+	// want a string.
+	Render() string
+	// RenderTo writes the node's HTML representation to w, streaming
+	// rather than building the whole document in memory.
+	RenderTo(w io.Writer) error
+}
+
+// writeStrings writes each of ss to w in order, stopping at the first
+// error.
+func writeStrings(w io.Writer, ss ...string) error {
+	for _, s := range ss {
+		if _, err := io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderString runs n's RenderTo into a buffer and returns the result,
+// for use by the Node.Render() string wrappers.
+func renderString(n Node) string {
+	var buf bytes.Buffer
+	n.RenderTo(&buf)
+	return buf.String()
+}
+
+// renderTo dispatches to a custom render function registered on m for
+// n's type, falling back to n's own RenderTo method.
+func renderTo(m *Mark, n Node, w io.Writer) error {
+	if m != nil {
+		if fn, ok := m.renderFnsTo[n.Type()]; ok {
+			fn(n, w)
+			return nil
+		}
+		if fn, ok := m.renderFns[n.Type()]; ok {
+			return writeStrings(w, fn(n))
+		}
+	}
+	return n.RenderTo(w)
+}
+
+func renderAllTo(m *Mark, nodes []Node, w io.Writer) error {
+	for _, n := range nodes {
+		if err := renderTo(m, n, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DocumentNode is the root of a parsed document. Footnotes collects
+// the `[^id]: ...` definitions found anywhere in the source; they are
+// rendered once, in a trailing footnotes section, rather than in
+// document order.
+type DocumentNode struct {
+	mark      *Mark
+	Nodes     []Node
+	Footnotes []*FootnoteDefNode
+}
+
+func (n *DocumentNode) Type() NodeType { return NodeDocument }
+func (n *DocumentNode) Render() string { return renderString(n) }
+func (n *DocumentNode) RenderTo(w io.Writer) error {
+	for i, c := range n.Nodes {
+		if i > 0 {
+			if err := writeStrings(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := renderTo(n.mark, c, w); err != nil {
+			return err
+		}
+	}
+	if len(n.Footnotes) == 0 {
+		return nil
+	}
+	if err := writeStrings(w, "\n<section class=\"footnotes\">\n<ol>\n"); err != nil {
+		return err
+	}
+	for _, f := range n.Footnotes {
+		if err := renderTo(n.mark, f, w); err != nil {
+			return err
+		}
+		if err := writeStrings(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return writeStrings(w, "</ol>\n</section>")
+}
+
+// ParagraphNode is a block of text, e.g. `<p>...</p>`.
+type ParagraphNode struct {
+	mark  *Mark
+	Nodes []Node
+}
+
+func (n *ParagraphNode) Type() NodeType { return NodeParagraph }
+func (n *ParagraphNode) Render() string { return renderString(n) }
+func (n *ParagraphNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<p>"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</p>")
+}
+
+// HeadingNode is an ATX (`#`) or Setext (`===`) heading. A `---`
+// underline is read as a thematic break, not a Setext heading.
+type HeadingNode struct {
+	mark  *Mark
+	Level int
+	ID    string
+	Nodes []Node
+}
+
+func (n *HeadingNode) Type() NodeType { return NodeHeading }
+func (n *HeadingNode) Render() string { return renderString(n) }
+func (n *HeadingNode) RenderTo(w io.Writer) error {
+	tag := "h" + strconv.Itoa(n.Level)
+	id := escapeHTML(n.ID)
+	if err := writeStrings(w, "<"+tag+" id=\""+id+"\">"); err != nil {
+		return err
+	}
+	if n.mark != nil && n.mark.opts.HeadingAnchorLink {
+		if err := writeStrings(w, "<a class=\"anchor\" href=\"#"+id+"\" aria-hidden=\"true\">#</a>"); err != nil {
+			return err
+		}
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</"+tag+">")
+}
+
+// TextNode is a run of plain (already escaped) text.
+type TextNode struct {
+	Text string
+}
+
+func (n *TextNode) Type() NodeType             { return NodeText }
+func (n *TextNode) Render() string             { return n.Text }
+func (n *TextNode) RenderTo(w io.Writer) error { return writeStrings(w, n.Text) }
+
+// StrongNode is `**bold**` / `__bold__` text.
+type StrongNode struct {
+	mark  *Mark
+	Nodes []Node
+}
+
+func (n *StrongNode) Type() NodeType { return NodeStrong }
+func (n *StrongNode) Render() string { return renderString(n) }
+func (n *StrongNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<strong>"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</strong>")
+}
+
+// EmphasisNode is `*italic*` / `_italic_` text.
+type EmphasisNode struct {
+	mark  *Mark
+	Nodes []Node
+}
+
+func (n *EmphasisNode) Type() NodeType { return NodeEmphasis }
+func (n *EmphasisNode) Render() string { return renderString(n) }
+func (n *EmphasisNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<em>"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</em>")
+}
+
+// DelNode is `~~struck~~` text.
+type DelNode struct {
+	mark  *Mark
+	Nodes []Node
+}
+
+func (n *DelNode) Type() NodeType { return NodeDel }
+func (n *DelNode) Render() string { return renderString(n) }
+func (n *DelNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<del>"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</del>")
+}
+
+// CodeNode is an inline code span, e.g. “ `code` “.
+type CodeNode struct {
+	Text string
+}
+
+func (n *CodeNode) Type() NodeType { return NodeCode }
+func (n *CodeNode) Render() string { return renderString(n) }
+func (n *CodeNode) RenderTo(w io.Writer) error {
+	return writeStrings(w, "<code>", n.Text, "</code>")
+}
+
+// CodeBlockNode is an indented or fenced code block. Lang is the
+// language tag following an opening GFM fence (e.g. "js" in
+// ```js), or empty for indented blocks and untagged fences. Source
+// holds the raw, un-escaped block contents for Options.Highlighter;
+// Text holds the HTML-escaped version used by the default rendering.
+type CodeBlockNode struct {
+	mark   *Mark
+	Text   string
+	Source string
+	Lang   string
+}
+
+func (n *CodeBlockNode) Type() NodeType { return NodeCodeBlock }
+func (n *CodeBlockNode) Render() string { return renderString(n) }
+func (n *CodeBlockNode) RenderTo(w io.Writer) error {
+	if n.mark != nil {
+		if html, ok := highlight(n.mark.opts, n.Lang, n.Source); ok {
+			return writeStrings(w, html)
+		}
+	}
+	class := ""
+	if n.Lang != "" {
+		class = " class=\"lang-" + escapeHTML(n.Lang) + "\""
+	}
+	return writeStrings(w, "<pre><code"+class+">", n.Text, "</code></pre>")
+}
+
+// LinkNode is `[text](href "title")`.
+type LinkNode struct {
+	mark  *Mark
+	Href  string
+	Title string
+	Nodes []Node
+}
+
+func (n *LinkNode) Type() NodeType { return NodeLink }
+func (n *LinkNode) Render() string { return renderString(n) }
+func (n *LinkNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<a href=\""+escapeHTML(n.Href)+"\""); err != nil {
+		return err
+	}
+	if n.Title != "" {
+		if err := writeStrings(w, " title=\""+escapeHTML(n.Title)+"\""); err != nil {
+			return err
+		}
+	}
+	if err := writeStrings(w, ">"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</a>")
+}
+
+// ImageNode is `![alt](src "title")`.
+type ImageNode struct {
+	Src   string
+	Alt   string
+	Title string
+}
+
+func (n *ImageNode) Type() NodeType { return NodeImage }
+func (n *ImageNode) Render() string { return renderString(n) }
+func (n *ImageNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<img src=\""+escapeHTML(n.Src)+"\" alt=\""+n.Alt+"\""); err != nil {
+		return err
+	}
+	if n.Title != "" {
+		if err := writeStrings(w, " title=\""+escapeHTML(n.Title)+"\""); err != nil {
+			return err
+		}
+	}
+	return writeStrings(w, ">")
+}
+
+// ListNode is an ordered (`<ol>`) or unordered (`<ul>`) list.
+type ListNode struct {
+	mark    *Mark
+	Ordered bool
+	Items   []Node
+}
+
+func (n *ListNode) Type() NodeType { return NodeList }
+func (n *ListNode) Render() string { return renderString(n) }
+func (n *ListNode) RenderTo(w io.Writer) error {
+	tag := "ul"
+	if n.Ordered {
+		tag = "ol"
+	}
+	if err := writeStrings(w, "<"+tag+">\n"); err != nil {
+		return err
+	}
+	for _, it := range n.Items {
+		if err := renderTo(n.mark, it, w); err != nil {
+			return err
+		}
+		if err := writeStrings(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return writeStrings(w, "</"+tag+">")
+}
+
+// ListItemNode is a single `<li>` entry of a ListNode.
+type ListItemNode struct {
+	mark  *Mark
+	Nodes []Node
+}
+
+func (n *ListItemNode) Type() NodeType { return NodeListItem }
+func (n *ListItemNode) Render() string { return renderString(n) }
+func (n *ListItemNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<li>"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</li>")
+}
+
+// HrNode is a `<hr>` thematic break.
+type HrNode struct{}
+
+func (n *HrNode) Type() NodeType             { return NodeHr }
+func (n *HrNode) Render() string             { return "<hr>" }
+func (n *HrNode) RenderTo(w io.Writer) error { return writeStrings(w, "<hr>") }
+
+// BrNode is a hard line break (`<br>`), produced by a trailing
+// double-space at the end of a line.
+type BrNode struct{}
+
+func (n *BrNode) Type() NodeType             { return NodeBr }
+func (n *BrNode) Render() string             { return "<br>" }
+func (n *BrNode) RenderTo(w io.Writer) error { return writeStrings(w, "<br>") }
+
+// RawHTMLNode is text that is emitted verbatim, used for HTML entity
+// references such as "&copy;". Autolinks become LinkNodes instead.
+type RawHTMLNode struct {
+	HTML string
+}
+
+func (n *RawHTMLNode) Type() NodeType             { return NodeRawHTML }
+func (n *RawHTMLNode) Render() string             { return n.HTML }
+func (n *RawHTMLNode) RenderTo(w io.Writer) error { return writeStrings(w, n.HTML) }
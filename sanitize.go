@@ -0,0 +1,213 @@
+package mark
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// SanitizerPolicy filters rendered HTML before it is returned from
+// Render. Implementations can wrap an external library such as
+// bluemonday; DefaultUGCPolicy provides a conservative built-in one so
+// callers don't need an external dependency for basic safety.
+type SanitizerPolicy interface {
+	Sanitize(html string) string
+}
+
+// ugcPolicy is a tokenizing allowlist-based SanitizerPolicy covering
+// the handful of tags and attributes mark itself ever emits: the
+// structural markup produced by the renderer, plus the `lang-xxx`
+// classes fenced code blocks add and the `type="checkbox"` attribute
+// task lists add. Everything else - unknown tags, event handlers,
+// `javascript:` URLs, raw `<script>`/`<style>` - is dropped.
+type ugcPolicy struct{}
+
+// DefaultUGCPolicy returns a conservative SanitizerPolicy suitable for
+// untrusted user-generated content: it allows the tags mark's own
+// renderer produces along with the `lang-xxx` classes emitted by
+// fenced code blocks and the checkbox attributes emitted by task
+// lists, and drops everything else instead of trying to pattern-match
+// dangerous markup out of the string.
+func DefaultUGCPolicy() SanitizerPolicy {
+	return ugcPolicy{}
+}
+
+// allowedTags lists the tags mark's renderer can produce, mirroring
+// the allowlist pattern used by Gitea's BuildSanitizer. Anything not
+// in this set is unwrapped: its children are kept, but the tag itself
+// is dropped.
+var allowedTags = map[atom.Atom]bool{
+	atom.P:       true,
+	atom.A:       true,
+	atom.Img:     true,
+	atom.Strong:  true,
+	atom.Em:      true,
+	atom.Del:     true,
+	atom.Code:    true,
+	atom.Pre:     true,
+	atom.Ul:      true,
+	atom.Ol:      true,
+	atom.Li:      true,
+	atom.Hr:      true,
+	atom.Br:      true,
+	atom.Sup:     true,
+	atom.Section: true,
+	atom.Table:   true,
+	atom.Thead:   true,
+	atom.Tbody:   true,
+	atom.Tr:      true,
+	atom.Th:      true,
+	atom.Td:      true,
+	atom.Input:   true,
+	atom.Span:    true,
+	atom.H1:      true,
+	atom.H2:      true,
+	atom.H3:      true,
+	atom.H4:      true,
+	atom.H5:      true,
+	atom.H6:      true,
+}
+
+var langClassRe = regexp.MustCompile(`^lang-[\w-]+$`)
+
+// allowedClass reports whether class belongs on tag: only the
+// `lang-xxx` classes fenced code blocks emit, the footnotes section's
+// `footnotes` class, the anchor link's `anchor` class, and the
+// highlighter's `ln` line spans and default `chroma` wrapper. A custom
+// CodeWrapperClass is not allowed.
+func allowedClass(tag atom.Atom, class string) bool {
+	switch {
+	case langClassRe.MatchString(class):
+		return tag == atom.Code || tag == atom.Pre
+	case class == "footnotes":
+		return tag == atom.Section
+	case class == "anchor":
+		return tag == atom.A
+	case class == "ln":
+		return tag == atom.Span
+	case class == "chroma":
+		return tag == atom.Pre
+	}
+	return false
+}
+
+// allowedAttr reports whether attribute key belongs on tag.
+func allowedAttr(tag atom.Atom, key string) bool {
+	switch key {
+	case "id":
+		return true
+	case "class":
+		return true
+	case "href":
+		return tag == atom.A
+	case "src", "alt":
+		return tag == atom.Img
+	case "title":
+		return tag == atom.A || tag == atom.Img
+	case "align":
+		return tag == atom.Th || tag == atom.Td
+	case "data-line":
+		return tag == atom.Span
+	case "aria-hidden":
+		return tag == atom.A
+	case "type":
+		return tag == atom.Input
+	case "checked", "disabled":
+		return tag == atom.Input
+	}
+	return false
+}
+
+// safeURL reports whether a URL is safe to keep in an href/src: no
+// javascript:, data:, or other script-executing scheme.
+func safeURL(u string) bool {
+	u = strings.TrimSpace(strings.ToLower(u))
+	if strings.HasPrefix(u, "#") || strings.HasPrefix(u, "/") {
+		return true
+	}
+	if i := strings.IndexByte(u, ':'); i >= 0 {
+		scheme := u[:i]
+		return scheme == "http" || scheme == "https" || scheme == "mailto"
+	}
+	return true
+}
+
+func (ugcPolicy) Sanitize(s string) string {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var out strings.Builder
+	// skipDepth tracks nesting inside a dropped <script>/<style>
+	// element so its text content is discarded along with it.
+	skipDepth := 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return out.String()
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(html.EscapeString(string(z.Text())))
+			}
+		case html.CommentToken, html.DoctypeToken:
+			// Dropped: never part of mark's own output.
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			tok := z.Token()
+			if tok.DataAtom == atom.Script || tok.DataAtom == atom.Style {
+				if tt == html.StartTagToken {
+					skipDepth++
+				} else if tt == html.EndTagToken && skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			if !allowedTags[tok.DataAtom] {
+				continue
+			}
+			writeSanitizedTag(&out, tok)
+		}
+	}
+}
+
+func writeSanitizedTag(out *strings.Builder, tok html.Token) {
+	out.WriteByte('<')
+	if tok.Type == html.EndTagToken {
+		out.WriteByte('/')
+	}
+	out.WriteString(tok.Data)
+	for _, a := range tok.Attr {
+		if !allowedAttr(tok.DataAtom, a.Key) {
+			continue
+		}
+		if a.Key == "class" && !allowedClass(tok.DataAtom, a.Val) {
+			continue
+		}
+		if (a.Key == "href" || a.Key == "src") && !safeURL(a.Val) {
+			continue
+		}
+		out.WriteByte(' ')
+		out.WriteString(a.Key)
+		if tok.DataAtom == atom.Input && (a.Key == "checked" || a.Key == "disabled") {
+			continue
+		}
+		out.WriteString("=\"")
+		out.WriteString(html.EscapeString(a.Val))
+		out.WriteByte('"')
+	}
+	if tok.Type == html.SelfClosingTagToken {
+		out.WriteString(" /")
+	}
+	out.WriteByte('>')
+}
+
+// sanitize runs opts' configured SanitizerPolicy over html, if
+// enabled, returning html unchanged otherwise.
+func sanitize(opts *Options, htm string) string {
+	if !opts.Sanitize || opts.SanitizerPolicy == nil {
+		return htm
+	}
+	return opts.SanitizerPolicy.Sanitize(htm)
+}
@@ -0,0 +1,47 @@
+package mark
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Sanitize = true
+	opts.SanitizerPolicy = DefaultUGCPolicy()
+
+	// Ordinary output, including the lang-xxx class fenced code blocks
+	// emit, passes through untouched.
+	in := "```js\nvar a;\n```"
+	want := "<pre><code class=\"lang-js\">var a;</code></pre>"
+	if got := New(in, opts).Render(); got != want {
+		t.Errorf("Sanitize: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestSanitizeDisabledByDefault(t *testing.T) {
+	if got := Render("foobar"); got != "<p>foobar</p>" {
+		t.Errorf("Render without Sanitize should be unaffected, got %+v", got)
+	}
+}
+
+func TestDefaultUGCPolicyBlocksAdversarialHTML(t *testing.T) {
+	policy := DefaultUGCPolicy()
+	cases := map[string]string{
+		// <script>/<style> elements are dropped along with their text
+		// content, not just pattern-matched out of the surrounding tags.
+		"<script>alert(1)</script><p>ok</p>":   "<p>ok</p>",
+		"<style>p{color:red}</style><p>ok</p>": "<p>ok</p>",
+		// Event handler attributes are dropped regardless of tag or
+		// whitespace, since allowedAttr never allowlists "on*".
+		`<p onmouseover="alert(1)">text</p>`: "<p>text</p>",
+		`<svg/onload="alert(1)">x</svg>`:     "x",
+		// javascript: URLs are dropped even though href is allowed on <a>.
+		`<a href="javascript:alert(1)">x</a>`: "<a>x</a>",
+		// Tags outside the allowlist are unwrapped: the tag is dropped
+		// but its text content survives.
+		"<iframe src=\"evil\">x</iframe>": "x",
+	}
+	for in, want := range cases {
+		if got := policy.Sanitize(in); got != want {
+			t.Errorf("Sanitize(%q): got\n%+v\nexpected\n%+v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mark
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Slugger turns heading text into the slug used for its HTML id.
+type Slugger interface {
+	Slug(text string) string
+}
+
+var slugInvalidRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// defaultSlugger lowercases text and replaces runs of non-letter,
+// non-number characters with a single hyphen, trimming leading and
+// trailing hyphens.
+type defaultSlugger struct{}
+
+func (defaultSlugger) Slug(text string) string {
+	s := strings.ToLower(text)
+	s = slugInvalidRe.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+// slugify computes a heading's HTML id: it asks m's Slugger for the
+// base slug, disambiguates collisions seen earlier in this Render()
+// call by appending "-1", "-2", ..., and applies HeadingIDPrefix.
+func (m *Mark) slugify(text string) string {
+	base := m.slugger.Slug(text)
+	count, seen := m.slugSeen[base]
+	if seen {
+		count++
+	}
+	m.slugSeen[base] = count
+	id := base
+	if count > 0 {
+		id = base + "-" + strconv.Itoa(count)
+	}
+	return m.opts.HeadingIDPrefix + id
+}
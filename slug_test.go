@@ -0,0 +1,77 @@
+package mark
+
+import "testing"
+
+func TestSlugUnicodeHeading(t *testing.T) {
+	got := New("# ΔΛΞ", nil).Render()
+	want := "<h1 id=\"δλξ\">ΔΛΞ</h1>"
+	if got != want {
+		t.Errorf("unicode heading: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestSlugDuplicateHeadings(t *testing.T) {
+	got := New("# foo\n## foo\n### foo", nil).Render()
+	want := "<h1 id=\"foo\">foo</h1>\n<h2 id=\"foo-1\">foo</h2>\n<h3 id=\"foo-2\">foo</h3>"
+	if got != want {
+		t.Errorf("duplicate headings: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestSlugHeadingIDPrefix(t *testing.T) {
+	opts := DefaultOptions()
+	opts.HeadingIDPrefix = "toc-"
+	got := New("# foo", opts).Render()
+	want := "<h1 id=\"toc-foo\">foo</h1>"
+	if got != want {
+		t.Errorf("heading id prefix: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestSlugCustomSlugger(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Slugger = upperSlugger{}
+	got := New("# foo", opts).Render()
+	want := "<h1 id=\"FOO\">foo</h1>"
+	if got != want {
+		t.Errorf("custom slugger: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+func TestSlugCustomSluggerEscaped(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Slugger = injectingSlugger{}
+	got := New("# foo", opts).Render()
+	want := "<h1 id=\"foo&quot; onmouseover=&quot;alert(1)\">foo</h1>"
+	if got != want {
+		t.Errorf("custom slugger output is escaped: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
+
+type injectingSlugger struct{}
+
+func (injectingSlugger) Slug(text string) string {
+	return text + `" onmouseover="alert(1)`
+}
+
+type upperSlugger struct{}
+
+func (upperSlugger) Slug(text string) string {
+	b := []byte(text)
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
+
+func TestHeadingAnchorLink(t *testing.T) {
+	opts := DefaultOptions()
+	opts.HeadingAnchorLink = true
+	got := New("# foo", opts).Render()
+	want := "<h1 id=\"foo\"><a class=\"anchor\" href=\"#foo\" aria-hidden=\"true\">#</a>foo</h1>"
+	if got != want {
+		t.Errorf("heading anchor link: got\n%+v\nexpected\n%+v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package mark
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Typographer controls which typographic substitutions applySmartypants
+// makes. With a nil *Typographer on Options, the pass runs only when
+// Options.Smartypants is set, which is shorthand for enabling all four.
+type Typographer struct {
+	// Dashes replaces `--` with an en-dash and `---` with an em-dash.
+	Dashes bool
+	// Ellipses replaces `...` with a single ellipsis character.
+	Ellipses bool
+	// Quotes curls straight `'` and `"` quotes, opening or closing
+	// based on the character preceding them.
+	Quotes bool
+	// Symbols replaces `(c)`, `(r)`, and `(tm)` with ©, ®, and ™.
+	Symbols bool
+}
+
+// typographer resolves the effective Typographer for o: an explicit
+// o.Typographer wins, otherwise o.Smartypants enables every
+// substitution, otherwise the pass is disabled.
+func (o *Options) typographer() *Typographer {
+	if o.Typographer != nil {
+		return o.Typographer
+	}
+	if o.Smartypants {
+		return &Typographer{Dashes: true, Ellipses: true, Quotes: true, Symbols: true}
+	}
+	return nil
+}
+
+var (
+	copyrightRe  = regexp.MustCompile(`(?i)\(c\)`)
+	registeredRe = regexp.MustCompile(`(?i)\(r\)`)
+	trademarkRe  = regexp.MustCompile(`(?i)\(tm\)`)
+)
+
+// applySmartypants performs the typographic substitutions enabled by
+// t on plain text content, before it is HTML-escaped. It runs as a
+// post-tokenization transform on TextNode content only (see
+// resolveDelims' appendText), so it never fires inside code spans or
+// code blocks, which carry their own Text untouched by resolveDelims.
+//
+// prev is the last raw byte rendered before s in the surrounding
+// paragraph (0 if s opens the paragraph), used to resolve curlQuotes'
+// leading character correctly even when a delimiter (e.g. *emphasis*)
+// split the paragraph into more than one chunk. It returns the
+// transformed text along with the last raw byte of s, so the caller
+// can thread it into the next chunk.
+func applySmartypants(s string, t *Typographer, prev byte) (string, byte) {
+	if t.Dashes {
+		s = strings.ReplaceAll(s, "---", "—")
+		s = strings.ReplaceAll(s, "--", "–")
+	}
+	if t.Ellipses {
+		s = strings.ReplaceAll(s, "...", "…")
+	}
+	if t.Symbols {
+		s = copyrightRe.ReplaceAllString(s, "©")
+		s = registeredRe.ReplaceAllString(s, "®")
+		s = trademarkRe.ReplaceAllString(s, "™")
+	}
+	last := prev
+	if len(s) > 0 {
+		last = s[len(s)-1]
+	}
+	if t.Quotes {
+		s, last = curlQuotes(s, prev)
+	}
+	return s, last
+}
+
+// curlQuotes replaces straight quotes with curly ones, opening when
+// the quote is at the start of the run or preceded by whitespace or
+// an opening bracket/dash, closing otherwise (which also covers
+// mid-word apostrophes, e.g. "don't" -> "don’t"). prev seeds the
+// context for a quote at s[0], so quote-openness is resolved correctly
+// across a chunk boundary (e.g. the apostrophe in "*foo*'s bar", which
+// scanInline splits into a separate chunk from "foo"). It returns the
+// curled text and the last raw byte of s, for the next chunk's prev.
+func curlQuotes(s string, prev byte) (string, byte) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			if isOpenQuoteContext(prev) {
+				b.WriteString("“")
+			} else {
+				b.WriteString("”")
+			}
+		case '\'':
+			if isOpenQuoteContext(prev) {
+				b.WriteString("‘")
+			} else {
+				b.WriteString("’")
+			}
+		default:
+			b.WriteByte(c)
+		}
+		prev = c
+	}
+	return b.String(), prev
+}
+
+// isOpenQuoteContext reports whether a quote preceded by prev opens a
+// quoted span. prev is 0 at the very start of the paragraph, which
+// counts as an opening context like whitespace or an opening bracket.
+func isOpenQuoteContext(prev byte) bool {
+	switch prev {
+	case 0, ' ', '\t', '\n', '(', '[', '{', '-':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package mark
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// TableNode is a GFM pipe table: a header row, a per-column alignment
+// row, and zero or more body rows.
+type TableNode struct {
+	mark   *Mark
+	Header *TableRowNode
+	Rows   []*TableRowNode
+}
+
+func (n *TableNode) Type() NodeType { return NodeTable }
+func (n *TableNode) Render() string { return renderString(n) }
+func (n *TableNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<table>\n<thead>\n"); err != nil {
+		return err
+	}
+	if err := renderTo(n.mark, n.Header, w); err != nil {
+		return err
+	}
+	if err := writeStrings(w, "\n</thead>\n<tbody>\n"); err != nil {
+		return err
+	}
+	for _, r := range n.Rows {
+		if err := renderTo(n.mark, r, w); err != nil {
+			return err
+		}
+		if err := writeStrings(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return writeStrings(w, "</tbody>\n</table>")
+}
+
+// TableRowNode is a single `<tr>` of a TableNode.
+type TableRowNode struct {
+	mark  *Mark
+	Cells []*TableCellNode
+}
+
+func (n *TableRowNode) Type() NodeType { return NodeTableRow }
+func (n *TableRowNode) Render() string { return renderString(n) }
+func (n *TableRowNode) RenderTo(w io.Writer) error {
+	if err := writeStrings(w, "<tr>"); err != nil {
+		return err
+	}
+	for _, c := range n.Cells {
+		if err := renderTo(n.mark, c, w); err != nil {
+			return err
+		}
+	}
+	return writeStrings(w, "</tr>")
+}
+
+// TableCellNode is a single `<th>`/`<td>` of a TableRowNode. Align is
+// one of "left", "center", "right", or "" for the column's default.
+type TableCellNode struct {
+	mark   *Mark
+	Header bool
+	Align  string
+	Nodes  []Node
+}
+
+func (n *TableCellNode) Type() NodeType { return NodeTableCell }
+func (n *TableCellNode) Render() string { return renderString(n) }
+func (n *TableCellNode) RenderTo(w io.Writer) error {
+	tag := "td"
+	if n.Header {
+		tag = "th"
+	}
+	align := ""
+	if n.Align != "" {
+		align = " align=\"" + n.Align + "\""
+	}
+	if err := writeStrings(w, "<"+tag+align+">"); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</"+tag+">")
+}
+
+var tableAlignCellRe = regexp.MustCompile(`^\s*:?-+:?\s*$`)
+
+// isTableStart reports whether lines[i] is a header row immediately
+// followed by a valid `|---|:---:|---:|` alignment row.
+func isTableStart(lines []string, i int) bool {
+	if !strings.Contains(lines[i], "|") || i+1 >= len(lines) {
+		return false
+	}
+	return isTableDelimiterRow(lines[i+1])
+}
+
+func isTableDelimiterRow(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || !strings.Contains(line, "-") {
+		return false
+	}
+	for _, cell := range splitTableRow(line) {
+		if !tableAlignCellRe.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	return strings.Split(line, "|")
+}
+
+func cellAlign(spec string) string {
+	spec = strings.TrimSpace(spec)
+	left := strings.HasPrefix(spec, ":")
+	right := strings.HasSuffix(spec, ":")
+	switch {
+	case left && right:
+		return "center"
+	case right:
+		return "right"
+	case left:
+		return "left"
+	default:
+		return ""
+	}
+}
+
+// parseTable consumes a header row, its alignment row, and all
+// following pipe-delimited rows.
+func parseTable(m *Mark, lines []string, i int) (Node, int) {
+	headerCells := splitTableRow(lines[i])
+	aligns := make([]string, len(headerCells))
+	for k, spec := range splitTableRow(lines[i+1]) {
+		if k < len(aligns) {
+			aligns[k] = cellAlign(spec)
+		}
+	}
+	header := &TableRowNode{mark: m}
+	for k, c := range headerCells {
+		header.Cells = append(header.Cells, &TableCellNode{
+			mark: m, Header: true, Align: aligns[k], Nodes: parseInline(m, strings.TrimSpace(c)),
+		})
+	}
+	table := &TableNode{mark: m, Header: header}
+	j := i + 2
+	for j < len(lines) && strings.TrimSpace(lines[j]) != "" && strings.Contains(lines[j], "|") {
+		row := &TableRowNode{mark: m}
+		for k, c := range splitTableRow(lines[j]) {
+			align := ""
+			if k < len(aligns) {
+				align = aligns[k]
+			}
+			row.Cells = append(row.Cells, &TableCellNode{mark: m, Align: align, Nodes: parseInline(m, strings.TrimSpace(c))})
+		}
+		table.Rows = append(table.Rows, row)
+		j++
+	}
+	return table, j
+}
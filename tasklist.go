@@ -0,0 +1,53 @@
+package mark
+
+import (
+	"io"
+	"regexp"
+)
+
+var taskItemRe = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+
+// TaskListItemNode is a GFM task list entry (`- [ ]` / `- [x]`),
+// rendered as a disabled checkbox followed by the item's content.
+type TaskListItemNode struct {
+	mark    *Mark
+	Checked bool
+	Nodes   []Node
+}
+
+func (n *TaskListItemNode) Type() NodeType { return NodeTaskListItem }
+func (n *TaskListItemNode) Render() string { return renderString(n) }
+func (n *TaskListItemNode) RenderTo(w io.Writer) error {
+	checked := ""
+	if n.Checked {
+		checked = " checked"
+	}
+	if err := writeStrings(w, "<li><input type=\"checkbox\""+checked+" disabled> "); err != nil {
+		return err
+	}
+	if err := renderAllTo(n.mark, n.Nodes, w); err != nil {
+		return err
+	}
+	return writeStrings(w, "</li>")
+}
+
+// newListItem builds a ListItemNode, or a TaskListItemNode if text
+// starts with a GFM task list marker.
+func newListItem(m *Mark, text string) Node {
+	if match := taskItemRe.FindStringSubmatch(text); match != nil {
+		checked := match[1] == "x" || match[1] == "X"
+		return &TaskListItemNode{mark: m, Checked: checked, Nodes: parseInline(m, match[2])}
+	}
+	return &ListItemNode{mark: m, Nodes: parseInline(m, text)}
+}
+
+// appendListItemChild appends a trailing child node (typically a
+// nested ListNode) to a list item regardless of its concrete type.
+func appendListItemChild(item Node, child Node) {
+	switch v := item.(type) {
+	case *ListItemNode:
+		v.Nodes = append(v.Nodes, child)
+	case *TaskListItemNode:
+		v.Nodes = append(v.Nodes, child)
+	}
+}